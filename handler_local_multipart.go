@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/filestore"
+)
+
+// handlerLocalMultipartPart receives one part of a resumable upload when the
+// server is configured with LocalFileStore instead of S3 — the local
+// counterpart to PUTting a part straight to a presigned S3 URL.
+func (cfg *apiConfig) handlerLocalMultipartPart(w http.ResponseWriter, r *http.Request) {
+	local, ok := cfg.fileStore.(*filestore.LocalFileStore)
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "Local multipart upload requires the local file store", nil)
+		return
+	}
+
+	partNumber, err := strconv.Atoi(r.PathValue("partNumber"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid part number", err)
+		return
+	}
+
+	etag, err := local.WritePart(r.PathValue("uploadID"), int32(partNumber), r.Body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't write part", err)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
+	"github.com/google/uuid"
+)
+
+// generateAndStoreThumbnail picks a frame near the start of the video at
+// filePath, uploads it to S3 as the video's thumbnail, and updates the
+// video record.
+func (cfg *apiConfig) generateAndStoreThumbnail(ctx context.Context, videoID uuid.UUID, filePath string) error {
+	duration, err := getVideoDuration(filePath)
+	if err != nil {
+		return fmt.Errorf("couldn't get video duration: %w", err)
+	}
+
+	return cfg.generateAndStoreThumbnailAt(ctx, videoID, filePath, duration*0.1)
+}
+
+func (cfg *apiConfig) generateAndStoreThumbnailAt(ctx context.Context, videoID uuid.UUID, filePath string, atSeconds float64) error {
+	thumbnail, err := generateThumbnailFromVideo(filePath, atSeconds)
+	if err != nil {
+		return fmt.Errorf("couldn't generate thumbnail: %w", err)
+	}
+
+	key := fmt.Sprintf("thumbnails/%s.jpg", videoID)
+	if err := cfg.fileStore.PutObject(ctx, key, "image/jpeg", bytes.NewReader(thumbnail)); err != nil {
+		return fmt.Errorf("couldn't upload thumbnail: %w", err)
+	}
+
+	video, err := cfg.db.GetVideo(videoID)
+	if err != nil {
+		return fmt.Errorf("couldn't find video: %w", err)
+	}
+
+	video.ThumbnailURL = &key
+
+	if err := cfg.db.UpdateVideo(video); err != nil {
+		return fmt.Errorf("couldn't update video: %w", err)
+	}
+
+	return nil
+}
+
+// handlerAutoThumbnail lets a user re-pick the thumbnail frame for a video
+// that's already been uploaded, without re-uploading the source file.
+func (cfg *apiConfig) handlerAutoThumbnail(w http.ResponseWriter, r *http.Request) {
+	videoIDString := r.PathValue("videoID")
+	videoID, err := uuid.Parse(videoIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	video, err := cfg.db.GetVideo(videoID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find video", err)
+		return
+	}
+
+	if video.UserID != userID {
+		respondWithError(w, http.StatusUnauthorized, "You do not have permission to set a thumbnail for this video", nil)
+		return
+	}
+
+	atSeconds, err := parseAtSeconds(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid t parameter", err)
+		return
+	}
+
+	// A raw mp4 can be read straight off a presigned URL. An HLS manifest
+	// can't: its rendition references are relative paths that lose their
+	// signature once resolved against a presigned master.m3u8 URL, so ffmpeg
+	// is pointed at our own playback endpoint instead, which serves the
+	// manifest pre-rewritten with working sub-playlist URLs.
+	var inputURL string
+	if video.VideoURL != nil && strings.HasSuffix(*video.VideoURL, ".m3u8") {
+		playbackPath, err := cfg.playbackURL(videoID, userID, clientIP(r))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't resolve video source", err)
+			return
+		}
+		inputURL = fmt.Sprintf("http://%s%s", r.Host, playbackPath)
+	} else {
+		signedVideo, err := cfg.dbVideoToSignedVideo(r.Context(), video)
+		if err != nil || signedVideo.VideoURL == nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't resolve video source", err)
+			return
+		}
+		inputURL = *signedVideo.VideoURL
+	}
+
+	if err := cfg.generateAndStoreThumbnailAt(r.Context(), videoID, inputURL, atSeconds); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate thumbnail", err)
+		return
+	}
+
+	video, err = cfg.db.GetVideo(videoID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find video", err)
+		return
+	}
+
+	video, err = cfg.dbVideoToPlaybackVideo(r.Context(), userID, clientIP(r), video)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate presigned URL", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, video)
+}
+
+func parseAtSeconds(query url.Values) (float64, error) {
+	raw := query.Get("t")
+	if raw == "" {
+		return 0, fmt.Errorf("missing t query parameter")
+	}
+	return strconv.ParseFloat(raw, 64)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
+	"github.com/google/uuid"
+)
+
+// handlerGetUploadProgress streams the upload/transcode progress of a video
+// as server-sent events, so the client can render a real progress bar
+// instead of a spinner. The stream ends once the video reaches a terminal
+// status (ready or failed) or the client disconnects.
+func (cfg *apiConfig) handlerGetUploadProgress(w http.ResponseWriter, r *http.Request) {
+	videoIDString := r.PathValue("videoID")
+	videoID, err := uuid.Parse(videoIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	video, err := cfg.db.GetVideo(videoID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find video", err)
+		return
+	}
+
+	if video.UserID != userID {
+		respondWithError(w, http.StatusUnauthorized, "You do not have permission to view this video's progress", nil)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming unsupported", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if progress, ok := cfg.uploadProgress.get(videoID); ok {
+				payload, err := json.Marshal(progress)
+				if err == nil {
+					fmt.Fprintf(w, "data: %s\n\n", payload)
+					flusher.Flush()
+				}
+			}
+
+			// Check terminal status every tick, not just when a progress
+			// entry still exists: handleTranscodeResult deletes the entry
+			// as soon as a job finishes, so a client that connects (or
+			// reconnects) after that would otherwise never see the stream
+			// close.
+			video, err := cfg.db.GetVideo(videoID)
+			if err == nil && (video.Status == "ready" || video.Status == "failed") {
+				return
+			}
+		}
+	}
+}
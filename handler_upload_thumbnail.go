@@ -1,14 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/base64"
-	"fmt"
 	"io"
 	"mime"
 	"net/http"
-	"os"
-	"path/filepath"
 
 	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
 	"github.com/google/uuid"
@@ -87,16 +85,12 @@ func (cfg *apiConfig) handlerUploadThumbnail(w http.ResponseWriter, r *http.Requ
 	randBytes := make([]byte, 32)
 	rand.Read(randBytes)
 
-	fileName := base64.RawURLEncoding.EncodeToString(randBytes) + fileExtension[0]
-	filePath := filepath.Join(cfg.assetsRoot, fileName)
-	err = os.WriteFile(filePath, fileData, 0644)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't write thumbnail to file", err)
+	key := "thumbnails/" + base64.RawURLEncoding.EncodeToString(randBytes) + fileExtension[0]
+	if err := cfg.fileStore.PutObject(r.Context(), key, mediaType, bytes.NewReader(fileData)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't write thumbnail", err)
 		return
 	}
-
-	thumbnailURL := fmt.Sprintf("http://localhost:%s/assets/%s", cfg.port, fileName)
-	video.ThumbnailURL = &thumbnailURL
+	video.ThumbnailURL = &key
 
 	err = cfg.db.UpdateVideo(video)
 	if err != nil {
@@ -104,5 +98,11 @@ func (cfg *apiConfig) handlerUploadThumbnail(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	video, err = cfg.dbVideoToPlaybackVideo(r.Context(), userID, clientIP(r), video)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate thumbnail URL", err)
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, video)
 }
@@ -13,13 +13,12 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"strings"
+	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
 	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/database"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/transcode"
 	"github.com/google/uuid"
 )
 
@@ -88,31 +87,60 @@ func (cfg *apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request)
 	}
 	defer os.Remove(tempFile.Name())
 
-	io.Copy(tempFile, file)
+	trackedFile := &progressReader{
+		r:        file,
+		store:    cfg.uploadProgress,
+		videoID:  videoID,
+		stage:    "receiving",
+		expected: header.Size,
+	}
+	io.Copy(tempFile, trackedFile)
 	tempFile.Seek(0, io.SeekStart)
 	tempFile.Close()
 
-	processedFilePath, err := processVideoForFastStart(tempFile.Name())
+	video, err = cfg.startVideoProcessing(r.Context(), video, tempFile.Name())
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't process video", err)
 		return
 	}
-	defer os.Remove(processedFilePath)
 
-	processedFile, err := os.Open(processedFilePath)
+	video, err = cfg.dbVideoToPlaybackVideo(r.Context(), userID, clientIP(r), video)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't open processed video", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't sign video URLs", err)
 		return
 	}
-	defer processedFile.Close()
+
+	respondWithJSON(w, http.StatusAccepted, video)
+}
+
+// startVideoProcessing runs the shared post-upload pipeline regardless of
+// how the mp4 at localPath got there (a direct upload or an assembled
+// multipart one): fast-start processing, aspect-ratio detection, an
+// auto-generated thumbnail if the video doesn't already have one, and
+// handing the result off to the async transcode queue. It returns the
+// video record as it stands once the job has been enqueued.
+func (cfg *apiConfig) startVideoProcessing(ctx context.Context, video database.Video, localPath string) (database.Video, error) {
+	processedFilePath, err := processVideoForFastStart(localPath)
+	if err != nil {
+		return video, fmt.Errorf("couldn't process video: %w", err)
+	}
+	// The transcode worker takes ownership of processedFilePath and cleans it
+	// up once the HLS ladder has been built from it; transferred tracks
+	// whether we actually got it there, so any early return below still
+	// cleans up after itself instead of leaking the file.
+	transferred := false
+	defer func() {
+		if !transferred {
+			os.Remove(processedFilePath)
+		}
+	}()
 
 	randBytes := make([]byte, 32)
 	rand.Read(randBytes)
 
-	aspectRatio, err := getVideoAspectRatio(tempFile.Name())
+	aspectRatio, err := getVideoAspectRatio(localPath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't get video aspect ratio", err)
-		return
+		return video, fmt.Errorf("couldn't get video aspect ratio: %w", err)
 	}
 
 	aspectRatioPrefix := "other"
@@ -123,35 +151,35 @@ func (cfg *apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request)
 		aspectRatioPrefix = "portrait"
 	}
 
-	fileKey := fmt.Sprintf("%s/%s.mp4", aspectRatioPrefix, base64.URLEncoding.EncodeToString(randBytes))
+	videoPrefix := fmt.Sprintf("%s/%s", aspectRatioPrefix, base64.URLEncoding.EncodeToString(randBytes))
 
-	_, err = cfg.s3Client.PutObject(r.Context(), &s3.PutObjectInput{
-		Bucket:      aws.String(cfg.s3Bucket),
-		Key:         aws.String(fileKey),
-		Body:        processedFile,
-		ContentType: aws.String(mediaType),
-	})
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't upload video", err)
-		return
+	if video.ThumbnailURL == nil {
+		if err := cfg.generateAndStoreThumbnail(ctx, video.ID, localPath); err != nil {
+			log.Printf("couldn't generate thumbnail for video %s: %v", video.ID, err)
+		} else if refreshed, err := cfg.db.GetVideo(video.ID); err != nil {
+			return video, fmt.Errorf("couldn't find video: %w", err)
+		} else {
+			video = refreshed
+		}
 	}
 
-	videoUrl := fmt.Sprintf("%s,%s", cfg.s3Bucket, fileKey)
-	video.VideoURL = &videoUrl
-
-	err = cfg.db.UpdateVideo(video)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't update video", err)
-		return
+	video.Status = "processing"
+	if err := cfg.db.UpdateVideo(video); err != nil {
+		return video, fmt.Errorf("couldn't update video: %w", err)
 	}
 
-	video, err = cfg.dbVideoToSignedVideo(video)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't generate presigned URL", err)
-		return
-	}
+	cfg.uploadProgress.set(video.ID, UploadProgress{Stage: "transcoding"})
 
-	respondWithJSON(w, http.StatusOK, video)
+	cfg.pendingTranscodes.Store(video.ID, pendingTranscode{
+		prefix: videoPrefix,
+	})
+	transferred = true
+	cfg.transcodeQueue.Enqueue(transcode.Job{
+		VideoID:   video.ID,
+		InputPath: processedFilePath,
+	})
+
+	return video, nil
 }
 
 func getVideoAspectRatio(filePath string) (string, error) {
@@ -188,6 +216,51 @@ func getVideoAspectRatio(filePath string) (string, error) {
 	return "other", nil
 }
 
+func getVideoDuration(filePath string) (float64, error) {
+	cmdStdout := new(bytes.Buffer)
+	cmd := exec.Command("ffprobe", "-v", "error", "-print_format", "json", "-show_format", filePath)
+	cmd.Stdout = cmdStdout
+	if err := cmd.Run(); err != nil {
+		return 0, err
+	}
+
+	var output struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.NewDecoder(cmdStdout).Decode(&output); err != nil {
+		return 0, err
+	}
+
+	duration, err := strconv.ParseFloat(output.Format.Duration, 64)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't parse duration %q: %w", output.Format.Duration, err)
+	}
+
+	return duration, nil
+}
+
+// generateThumbnailFromVideo extracts a single JPEG frame from filePath at
+// atSeconds and returns its bytes.
+func generateThumbnailFromVideo(filePath string, atSeconds float64) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("ffmpeg",
+		"-ss", fmt.Sprintf("%.2f", atSeconds),
+		"-i", filePath,
+		"-frames:v", "1",
+		"-vf", "scale=1280:-1",
+		"-f", "image2",
+		"pipe:1",
+	)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("couldn't extract thumbnail frame: %w", err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
 func processVideoForFastStart(filePath string) (string, error) {
 	outputPath := filePath + ".processing"
 
@@ -200,40 +273,63 @@ func processVideoForFastStart(filePath string) (string, error) {
 	return outputPath, nil
 }
 
-func generatePresignedURL(s3Client *s3.Client, bucket, key string, expireTime time.Duration) (string, error) {
-	pClient := s3.NewPresignClient(s3Client)
-
-	presignedUrl, err := pClient.PresignGetObject(context.TODO(), &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	}, s3.WithPresignExpires(expireTime))
-	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned URL: %s", err)
+// dbVideoToSignedVideo resolves the keys stored in VideoURL and
+// ThumbnailURL into fetchable URLs via cfg.fileStore. Both the S3 and local
+// backends implement PresignGet, so this works the same regardless of which
+// one is configured.
+//
+// This hands back a raw, long-lived presigned URL for VideoURL, which is
+// only appropriate for the server's own internal use (e.g. handing a URL to
+// ffmpeg, or resolving the real destination inside the /playback redirect).
+// Anything destined for a client should go through dbVideoToPlaybackVideo
+// instead.
+func (cfg *apiConfig) dbVideoToSignedVideo(ctx context.Context, video database.Video) (database.Video, error) {
+	if video.ThumbnailURL != nil {
+		signedThumbnailUrl, err := cfg.fileStore.PresignGet(ctx, *video.ThumbnailURL, time.Hour*1)
+		if err != nil {
+			return video, err
+		}
+		video.ThumbnailURL = &signedThumbnailUrl
 	}
 
-	return presignedUrl.URL, nil
-}
-
-func (cfg *apiConfig) dbVideoToSignedVideo(video database.Video) (database.Video, error) {
 	if video.VideoURL == nil {
 		log.Printf("Video %s has no URL", video.ID)
 		return video, nil
 	}
 
-	split := strings.Split(*video.VideoURL, ",")
+	signedUrl, err := cfg.fileStore.PresignGet(ctx, *video.VideoURL, time.Hour*1)
+	if err != nil {
+		return video, err
+	}
+	video.VideoURL = &signedUrl
 
-	if len(split) != 2 {
-		log.Printf("Video %s has invalid URL: %s", video.ID, *video.VideoURL)
-		return video, nil
+	return video, nil
+}
+
+// dbVideoToPlaybackVideo is the client-facing counterpart of
+// dbVideoToSignedVideo: it points VideoURL at the stable /playback endpoint
+// (so access can be revoked or rate-limited) instead of a raw presigned S3
+// link, while still presigning ThumbnailURL directly since thumbnails carry
+// no such requirement.
+func (cfg *apiConfig) dbVideoToPlaybackVideo(ctx context.Context, userID uuid.UUID, clientIP string, video database.Video) (database.Video, error) {
+	if video.ThumbnailURL != nil {
+		signedThumbnailUrl, err := cfg.fileStore.PresignGet(ctx, *video.ThumbnailURL, time.Hour*1)
+		if err != nil {
+			return video, err
+		}
+		video.ThumbnailURL = &signedThumbnailUrl
 	}
 
-	bucket, key := split[0], split[1]
+	if video.VideoURL == nil {
+		log.Printf("Video %s has no URL", video.ID)
+		return video, nil
+	}
 
-	presignedUrl, err := generatePresignedURL(cfg.s3Client, bucket, key, time.Hour*1)
+	playbackUrl, err := cfg.playbackURL(video.ID, userID, clientIP)
 	if err != nil {
 		return video, err
 	}
+	video.VideoURL = &playbackUrl
 
-	video.VideoURL = &presignedUrl
 	return video, nil
 }
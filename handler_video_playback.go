@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/playback"
+	"github.com/google/uuid"
+)
+
+// playbackURL builds the stable, app-hosted URL the frontend should fetch a
+// video through instead of a raw presigned S3 link.
+func (cfg *apiConfig) playbackURL(videoID, userID uuid.UUID, clientIP string) (string, error) {
+	token, err := cfg.playbackIssuer.IssueToken(videoID, userID, clientIP)
+	if err != nil {
+		return "", fmt.Errorf("couldn't issue playback token: %w", err)
+	}
+
+	return fmt.Sprintf("/api/videos/%s/playback?token=%s", videoID, token), nil
+}
+
+// playbackTokenError pairs a rejection reason with the HTTP status it should
+// produce, so a single check can be shared between the playback and
+// rendition handlers.
+type playbackTokenError struct {
+	status int
+	msg    string
+	err    error
+}
+
+func (e *playbackTokenError) Error() string { return e.msg }
+
+func (cfg *apiConfig) checkPlaybackToken(r *http.Request, videoID uuid.UUID, token string) (*playback.Claims, *playbackTokenError) {
+	if token == "" {
+		return nil, &playbackTokenError{http.StatusUnauthorized, "Missing playback token", nil}
+	}
+
+	claims, err := cfg.playbackIssuer.ValidateToken(token)
+	if err != nil {
+		return nil, &playbackTokenError{http.StatusUnauthorized, "Invalid playback token", err}
+	}
+
+	if claims.VideoID != videoID {
+		return nil, &playbackTokenError{http.StatusUnauthorized, "Token does not match this video", nil}
+	}
+
+	if clientIP(r) != claims.ClientIP {
+		return nil, &playbackTokenError{http.StatusUnauthorized, "Token was issued to a different client", nil}
+	}
+
+	if !cfg.playbackLimiter.Allow(claims.UserID) {
+		return nil, &playbackTokenError{http.StatusTooManyRequests, "Too many playback requests", nil}
+	}
+
+	return claims, nil
+}
+
+// handlerVideoPlayback validates a playback token and serves the video. A
+// plain mp4 is served by redirecting to a freshly presigned URL. An HLS
+// manifest can't be redirected to directly: its rendition references are
+// relative paths, and resolving a relative path against a presigned URL
+// drops the query-string signature, so every sub-playlist and segment fetch
+// would 403 against a private bucket. Instead the manifest is fetched and
+// rewritten so each reference points back through playback/rendition, which
+// repeats the same token check before presigning the next file down.
+func (cfg *apiConfig) handlerVideoPlayback(w http.ResponseWriter, r *http.Request) {
+	videoIDString := r.PathValue("videoID")
+	videoID, err := uuid.Parse(videoIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	claims, tokErr := cfg.checkPlaybackToken(r, videoID, token)
+	if tokErr != nil {
+		respondWithError(w, tokErr.status, tokErr.msg, tokErr.err)
+		return
+	}
+
+	video, err := cfg.db.GetVideo(videoID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't find video", err)
+		return
+	}
+
+	if video.VideoURL == nil {
+		respondWithError(w, http.StatusNotFound, "Video is not ready yet", nil)
+		return
+	}
+
+	if !strings.HasSuffix(*video.VideoURL, ".m3u8") {
+		signedUrl, err := cfg.fileStore.PresignGet(r.Context(), *video.VideoURL, time.Hour*1)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't generate playback URL", err)
+			return
+		}
+		http.Redirect(w, r, signedUrl, http.StatusFound)
+		return
+	}
+
+	// A master playlist is normally fetched once up front, so the short-lived
+	// token this request was validated with would expire partway through any
+	// video longer than TokenTTL if we baked it into every rewritten URL.
+	// Mint a longer-lived segment token to embed instead.
+	segmentToken, err := cfg.playbackIssuer.IssueSegmentToken(videoID, claims.UserID, claims.ClientIP)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't prepare playback manifest", err)
+		return
+	}
+
+	if err := cfg.rewriteAndServePlaylist(w, r, videoID, *video.VideoURL, "", segmentToken); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't serve playback manifest", err)
+	}
+}
+
+// handlerVideoPlaybackRendition serves one file out of an HLS ladder: a
+// rendition's media playlist (itself rewritten the same way as the master
+// playlist) or one of its .ts segments (redirected to a freshly presigned
+// URL). relPath is always relative to the master playlist's directory.
+func (cfg *apiConfig) handlerVideoPlaybackRendition(w http.ResponseWriter, r *http.Request) {
+	videoIDString := r.PathValue("videoID")
+	videoID, err := uuid.Parse(videoIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID", err)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if _, tokErr := cfg.checkPlaybackToken(r, videoID, token); tokErr != nil {
+		respondWithError(w, tokErr.status, tokErr.msg, tokErr.err)
+		return
+	}
+
+	relPath := r.URL.Query().Get("path")
+	if relPath == "" || strings.Contains(relPath, "..") {
+		respondWithError(w, http.StatusBadRequest, "Invalid rendition path", nil)
+		return
+	}
+
+	video, err := cfg.db.GetVideo(videoID)
+	if err != nil || video.VideoURL == nil {
+		respondWithError(w, http.StatusNotFound, "Video is not ready yet", err)
+		return
+	}
+
+	key := path.Join(path.Dir(*video.VideoURL), relPath)
+
+	if strings.HasSuffix(relPath, ".m3u8") {
+		if err := cfg.rewriteAndServePlaylist(w, r, videoID, key, path.Dir(relPath), token); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't serve rendition playlist", err)
+		}
+		return
+	}
+
+	signedUrl, err := cfg.fileStore.PresignGet(r.Context(), key, time.Hour*1)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate segment URL", err)
+		return
+	}
+	http.Redirect(w, r, signedUrl, http.StatusFound)
+}
+
+// rewriteAndServePlaylist fetches the m3u8 at playlistKey and rewrites every
+// line that isn't a comment into an absolute playback/rendition URL, joining
+// baseRelDir onto it first so the rewritten path stays relative to the
+// master playlist's directory. baseRelDir is "" for the master playlist
+// itself and the rendition's own directory (e.g. "720p") when rewriting a
+// media playlist.
+func (cfg *apiConfig) rewriteAndServePlaylist(w http.ResponseWriter, r *http.Request, videoID uuid.UUID, playlistKey, baseRelDir, token string) error {
+	body, err := cfg.fileStore.GetObject(r.Context(), playlistKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimRight(line, "\r")
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, "#"):
+			out.WriteString(trimmed)
+		default:
+			relPath := path.Join(baseRelDir, trimmed)
+			fmt.Fprintf(&out, "/api/videos/%s/playback/rendition?token=%s&path=%s",
+				videoID, url.QueryEscape(token), url.QueryEscape(relPath))
+		}
+		out.WriteString("\n")
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.WriteHeader(http.StatusOK)
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+// clientIP returns the request's remote address with any port stripped, so
+// it can be compared against the IP a playback token was issued to.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,29 @@
+// Package filestore abstracts where uploaded objects (videos, thumbnails,
+// HLS segments) end up, so the rest of the server doesn't have to know
+// whether it's talking to S3 or the local filesystem.
+package filestore
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// CompletedPart identifies one part of a finished multipart upload.
+type CompletedPart struct {
+	PartNumber int32
+	ETag       string
+}
+
+// FileStore is implemented by S3FileStore and LocalFileStore.
+type FileStore interface {
+	PutObject(ctx context.Context, key, contentType string, body io.Reader) error
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+	DeleteObject(ctx context.Context, key string) error
+
+	CreateMultipartUpload(ctx context.Context, key, contentType string) (uploadID string, err error)
+	PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int32, ttl time.Duration) (string, error)
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
@@ -0,0 +1,156 @@
+package filestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocalFileStore writes objects under a root directory on disk and serves
+// them back out via a base URL (typically the app's own /assets route).
+// It exists so contributors can run and test the app without AWS
+// credentials.
+type LocalFileStore struct {
+	root    string
+	baseURL string // e.g. "http://localhost:8091/assets"
+}
+
+// NewLocalFileStore returns a FileStore that reads and writes files under
+// root, and serves them back at baseURL + "/" + key.
+func NewLocalFileStore(root, baseURL string) *LocalFileStore {
+	return &LocalFileStore{root: root, baseURL: baseURL}
+}
+
+func (l *LocalFileStore) path(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(key))
+}
+
+func (l *LocalFileStore) PutObject(ctx context.Context, key, contentType string, body io.Reader) error {
+	path := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("couldn't create directory for %s: %w", key, err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("couldn't create %s: %w", key, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, body); err != nil {
+		return fmt.Errorf("couldn't write %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (l *LocalFileStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(l.path(key))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open %s: %w", key, err)
+	}
+	return f, nil
+}
+
+// PresignGet ignores ttl: local files are served for as long as they exist
+// on disk, there's no way to expire a plain http URL.
+func (l *LocalFileStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return fmt.Sprintf("%s/%s", l.baseURL, key), nil
+}
+
+func (l *LocalFileStore) DeleteObject(ctx context.Context, key string) error {
+	if err := os.Remove(l.path(key)); err != nil {
+		return fmt.Errorf("couldn't delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// multipartDir returns where an in-progress upload's parts and target key
+// are stashed until CompleteMultipartUpload assembles them.
+func (l *LocalFileStore) multipartDir(uploadID string) string {
+	return filepath.Join(l.root, ".multipart", uploadID)
+}
+
+func (l *LocalFileStore) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	uploadID := uuid.NewString()
+	if err := os.MkdirAll(l.multipartDir(uploadID), 0755); err != nil {
+		return "", fmt.Errorf("couldn't start local multipart upload for %s: %w", key, err)
+	}
+	return uploadID, nil
+}
+
+// PresignUploadPart has no S3 bucket to hand out a presigned PUT for, so it
+// points the caller at this server's own local-multipart endpoint instead;
+// WritePart is the other half of that contract.
+func (l *LocalFileStore) PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int32, ttl time.Duration) (string, error) {
+	base, err := url.Parse(l.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("couldn't build local part URL: %w", err)
+	}
+	base.Path = fmt.Sprintf("/api/local-multipart/%s/%d", uploadID, partNumber)
+	return base.String(), nil
+}
+
+// WritePart stores one part of a local multipart upload and returns an ETag
+// identifying its contents, mirroring what S3 would return from UploadPart.
+func (l *LocalFileStore) WritePart(uploadID string, partNumber int32, body io.Reader) (string, error) {
+	partPath := filepath.Join(l.multipartDir(uploadID), fmt.Sprintf("part%05d", partNumber))
+
+	f, err := os.Create(partPath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't write part %d: %w", partNumber, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), body); err != nil {
+		return "", fmt.Errorf("couldn't write part %d: %w", partNumber, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (l *LocalFileStore) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	destPath := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("couldn't create directory for %s: %w", key, err)
+	}
+
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("couldn't create %s: %w", key, err)
+	}
+	defer dest.Close()
+
+	dir := l.multipartDir(uploadID)
+	for _, p := range parts {
+		if err := appendPart(dest, filepath.Join(dir, fmt.Sprintf("part%05d", p.PartNumber))); err != nil {
+			return fmt.Errorf("couldn't assemble part %d of %s: %w", p.PartNumber, key, err)
+		}
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func appendPart(dest io.Writer, partPath string) error {
+	f, err := os.Open(partPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(dest, f)
+	return err
+}
+
+func (l *LocalFileStore) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	return os.RemoveAll(l.multipartDir(uploadID))
+}
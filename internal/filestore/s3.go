@@ -0,0 +1,143 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// multipartPartSize is the per-part size of PutObject's uploader: a body
+// larger than this goes up as a multipart upload in parts of this size,
+// anything smaller in a single PUT.
+const multipartPartSize = 16 * 1024 * 1024 // 16 MB
+
+// S3FileStore stores objects in a single S3 bucket.
+type S3FileStore struct {
+	client   *s3.Client
+	bucket   string
+	uploader *manager.Uploader
+}
+
+// NewS3FileStore returns a FileStore backed by bucket.
+func NewS3FileStore(client *s3.Client, bucket string) *S3FileStore {
+	return &S3FileStore{
+		client: client,
+		bucket: bucket,
+		uploader: manager.NewUploader(client, func(u *manager.Uploader) {
+			u.PartSize = multipartPartSize
+			u.Concurrency = 4
+		}),
+	}
+}
+
+func (s *S3FileStore) PutObject(ctx context.Context, key, contentType string, body io.Reader) error {
+	_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't put %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3FileStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get %s: %w", key, err)
+	}
+	return out.Body, nil
+}
+
+func (s *S3FileStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(s.client)
+	presigned, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("couldn't presign %s: %w", key, err)
+	}
+	return presigned.URL, nil
+}
+
+func (s *S3FileStore) DeleteObject(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3FileStore) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("couldn't create multipart upload for %s: %w", key, err)
+	}
+	return *out.UploadId, nil
+}
+
+func (s *S3FileStore) PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int32, ttl time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(s.client)
+	presigned, err := presignClient.PresignUploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int32(partNumber),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("couldn't presign part %d of %s: %w", partNumber, key, err)
+	}
+	return presigned.URL, nil
+}
+
+func (s *S3FileStore) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	completed := make([]types.CompletedPart, len(parts))
+	for i, p := range parts {
+		completed[i] = types.CompletedPart{
+			PartNumber: aws.Int32(p.PartNumber),
+			ETag:       aws.String(p.ETag),
+		}
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't complete multipart upload for %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3FileStore) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't abort multipart upload for %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Package playback issues and validates the short-lived tokens that gate
+// access to a video's /playback endpoint, so the server can hand out a
+// stable URL instead of a long-lived presigned S3 link.
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// TokenTTL is how long an issued playback token remains valid.
+const TokenTTL = 5 * time.Minute
+
+// SegmentTokenTTL is how long a token embedded into a rewritten HLS
+// manifest remains valid. It's deliberately longer than TokenTTL: a master
+// or rendition playlist is normally fetched once up front, and a short TTL
+// baked into every segment URL it lists would expire partway through any
+// video longer than TokenTTL.
+const SegmentTokenTTL = time.Hour
+
+var ErrInvalidToken = errors.New("invalid playback token")
+
+type claims struct {
+	VideoID  uuid.UUID `json:"videoID"`
+	UserID   uuid.UUID `json:"userID"`
+	ClientIP string    `json:"clientIP"`
+	jwt.RegisteredClaims
+}
+
+// Claims is the validated, caller-facing result of ValidateToken.
+type Claims struct {
+	VideoID  uuid.UUID
+	UserID   uuid.UUID
+	ClientIP string
+}
+
+// KeySet holds the signing keys a playback Issuer rotates through, indexed
+// by "kid". Dropping a kid from Keys invalidates every token it signed.
+type KeySet struct {
+	ActiveKid string
+	Keys      map[string][]byte // kid -> HMAC secret
+}
+
+// Issuer mints and validates playback tokens against a KeySet.
+type Issuer struct {
+	keys KeySet
+}
+
+// NewIssuer returns an Issuer that signs new tokens with keys.ActiveKid and
+// validates tokens against any key still present in keys.Keys.
+func NewIssuer(keys KeySet) *Issuer {
+	return &Issuer{keys: keys}
+}
+
+// IssueToken mints a token good for TokenTTL, bound to videoID, userID, and
+// the client's IP address.
+func (i *Issuer) IssueToken(videoID, userID uuid.UUID, clientIP string) (string, error) {
+	return i.issueToken(videoID, userID, clientIP, TokenTTL)
+}
+
+// IssueSegmentToken mints a token good for SegmentTokenTTL, bound the same
+// way as IssueToken. Use it for the tokens embedded into a rewritten HLS
+// manifest's sub-playlist and segment URLs, rather than forwarding the
+// short-lived token the initial /playback request was validated with.
+func (i *Issuer) IssueSegmentToken(videoID, userID uuid.UUID, clientIP string) (string, error) {
+	return i.issueToken(videoID, userID, clientIP, SegmentTokenTTL)
+}
+
+func (i *Issuer) issueToken(videoID, userID uuid.UUID, clientIP string, ttl time.Duration) (string, error) {
+	secret, ok := i.keys.Keys[i.keys.ActiveKid]
+	if !ok {
+		return "", fmt.Errorf("no signing key for active kid %q", i.keys.ActiveKid)
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		VideoID:  videoID,
+		UserID:   userID,
+		ClientIP: clientIP,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	token.Header["kid"] = i.keys.ActiveKid
+
+	return token.SignedString(secret)
+}
+
+// ValidateToken checks a token's signature, expiry, and that it was signed
+// with a key still present in the keyset.
+func (i *Issuer) ValidateToken(tokenString string) (*Claims, error) {
+	var parsed claims
+	_, err := jwt.ParseWithClaims(tokenString, &parsed, func(t *jwt.Token) (any, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		secret, ok := i.keys.Keys[kid]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return &Claims{
+		VideoID:  parsed.VideoID,
+		UserID:   parsed.UserID,
+		ClientIP: parsed.ClientIP,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package playback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func testKeySet() KeySet {
+	return KeySet{
+		ActiveKid: "k1",
+		Keys: map[string][]byte{
+			"k1": []byte("test-secret-k1"),
+		},
+	}
+}
+
+func TestIssueAndValidateToken(t *testing.T) {
+	issuer := NewIssuer(testKeySet())
+	videoID := uuid.New()
+	userID := uuid.New()
+
+	token, err := issuer.IssueToken(videoID, userID, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("IssueToken returned error: %v", err)
+	}
+
+	claims, err := issuer.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken returned error: %v", err)
+	}
+
+	if claims.VideoID != videoID || claims.UserID != userID || claims.ClientIP != "127.0.0.1" {
+		t.Fatalf("claims %+v don't match issued values", claims)
+	}
+}
+
+func TestValidateToken_Expired(t *testing.T) {
+	keys := testKeySet()
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		VideoID:  uuid.New(),
+		UserID:   uuid.New(),
+		ClientIP: "127.0.0.1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now.Add(-2 * TokenTTL)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(-TokenTTL)),
+		},
+	})
+	token.Header["kid"] = keys.ActiveKid
+	signed, err := token.SignedString(keys.Keys[keys.ActiveKid])
+	if err != nil {
+		t.Fatalf("couldn't sign test token: %v", err)
+	}
+
+	issuer := NewIssuer(keys)
+	if _, err := issuer.ValidateToken(signed); err == nil {
+		t.Fatal("expected an expired token to be rejected")
+	}
+}
+
+func TestValidateToken_WrongKid(t *testing.T) {
+	keys := testKeySet()
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		VideoID:  uuid.New(),
+		UserID:   uuid.New(),
+		ClientIP: "127.0.0.1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TokenTTL)),
+		},
+	})
+	token.Header["kid"] = "retired-key"
+	signed, err := token.SignedString([]byte("some-other-secret"))
+	if err != nil {
+		t.Fatalf("couldn't sign test token: %v", err)
+	}
+
+	issuer := NewIssuer(keys)
+	if _, err := issuer.ValidateToken(signed); err == nil {
+		t.Fatal("expected a token signed with an unknown kid to be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package playback
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RateLimiter caps how many playback requests a single user can make in a
+// sliding window, so one leaked token can't be used to hammer S3/CloudFront.
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[uuid.UUID][]time.Time
+}
+
+// NewRateLimiter allows up to limit requests per user within window.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   make(map[uuid.UUID][]time.Time),
+	}
+}
+
+// Allow reports whether userID may make another request right now.
+func (r *RateLimiter) Allow(userID uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-r.window)
+
+	recent := r.hits[userID][:0]
+	for _, t := range r.hits[userID] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= r.limit {
+		r.hits[userID] = recent
+		return false
+	}
+
+	r.hits[userID] = append(recent, now)
+	return true
+}
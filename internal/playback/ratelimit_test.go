@@ -0,0 +1,42 @@
+package playback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	limiter := NewRateLimiter(2, 50*time.Millisecond)
+	userID := uuid.New()
+
+	if !limiter.Allow(userID) {
+		t.Fatal("expected the first request to be allowed")
+	}
+	if !limiter.Allow(userID) {
+		t.Fatal("expected the second request to be allowed")
+	}
+	if limiter.Allow(userID) {
+		t.Fatal("expected the third request within the window to be rejected")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if !limiter.Allow(userID) {
+		t.Fatal("expected a request after the window elapsed to be allowed again")
+	}
+}
+
+func TestRateLimiter_PerUser(t *testing.T) {
+	limiter := NewRateLimiter(1, time.Minute)
+	userA := uuid.New()
+	userB := uuid.New()
+
+	if !limiter.Allow(userA) {
+		t.Fatal("expected userA's first request to be allowed")
+	}
+	if !limiter.Allow(userB) {
+		t.Fatal("expected userB's limit to be tracked independently of userA's")
+	}
+}
@@ -0,0 +1,156 @@
+package transcode
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// Rendition describes one fixed-bitrate output the HLS pipeline produces.
+type Rendition struct {
+	Name    string // used as the output subdirectory, e.g. "720p"
+	Width   int
+	Height  int
+	Bitrate string // e.g. "2500k", passed straight to ffmpeg's -b:v
+}
+
+// DefaultRenditions is the ladder used when a caller doesn't supply its own.
+var DefaultRenditions = []Rendition{
+	{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "5000k"},
+	{Name: "720p", Width: 1280, Height: 720, Bitrate: "2800k"},
+	{Name: "480p", Width: 854, Height: 480, Bitrate: "1400k"},
+	{Name: "360p", Width: 640, Height: 360, Bitrate: "800k"},
+}
+
+// Job is a single video waiting to be transcoded into an HLS ladder.
+type Job struct {
+	VideoID   uuid.UUID
+	InputPath string
+	// Renditions overrides DefaultRenditions when non-nil.
+	Renditions []Rendition
+}
+
+// Result is handed to a Queue's onComplete callback once a Job finishes.
+type Result struct {
+	VideoID    uuid.UUID
+	InputPath  string // the Job's InputPath, so the caller can clean it up
+	OutputDir  string // local directory containing master.m3u8 and per-rendition segments
+	MasterPath string
+	Err        error
+}
+
+// Queue runs transcode Jobs on a bounded pool of workers so that an
+// HTTP handler can hand off a video and return immediately.
+type Queue struct {
+	jobs       chan Job
+	onComplete func(Result)
+}
+
+// NewQueue starts workers goroutines pulling from an internal job channel.
+// onComplete is invoked from a worker goroutine once a job finishes (or fails).
+func NewQueue(workers int, onComplete func(Result)) *Queue {
+	q := &Queue{
+		jobs:       make(chan Job, 16),
+		onComplete: onComplete,
+	}
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+// Enqueue adds a job to the queue. It blocks if the queue is full.
+func (q *Queue) Enqueue(job Job) {
+	q.jobs <- job
+}
+
+func (q *Queue) worker() {
+	for job := range q.jobs {
+		outputDir, masterPath, err := BuildHLS(job.InputPath, job.Renditions)
+		q.onComplete(Result{
+			VideoID:    job.VideoID,
+			InputPath:  job.InputPath,
+			OutputDir:  outputDir,
+			MasterPath: masterPath,
+			Err:        err,
+		})
+	}
+}
+
+// BuildHLS transcodes inputPath into an HLS ladder: one media playlist and
+// segment set per rendition, plus a master.m3u8 referencing all of them.
+// It returns the temporary directory holding the output and the path to
+// master.m3u8 within it. The caller is responsible for removing outputDir
+// once its contents have been uploaded.
+func BuildHLS(inputPath string, renditions []Rendition) (outputDir, masterPath string, err error) {
+	if renditions == nil {
+		renditions = DefaultRenditions
+	}
+
+	outputDir, err = os.MkdirTemp("", "tubley-hls")
+	if err != nil {
+		return "", "", fmt.Errorf("couldn't create output dir: %w", err)
+	}
+
+	for _, r := range renditions {
+		renditionDir := filepath.Join(outputDir, r.Name)
+		if err := os.Mkdir(renditionDir, 0755); err != nil {
+			return outputDir, "", fmt.Errorf("couldn't create rendition dir: %w", err)
+		}
+
+		playlistPath := filepath.Join(renditionDir, "stream.m3u8")
+		segmentPattern := filepath.Join(renditionDir, "segment%03d.ts")
+
+		cmd := exec.Command("ffmpeg",
+			"-i", inputPath,
+			"-vf", fmt.Sprintf("scale=%d:%d", r.Width, r.Height),
+			"-c:a", "aac",
+			"-c:v", "libx264",
+			"-b:v", r.Bitrate,
+			"-hls_time", "6",
+			"-hls_playlist_type", "vod",
+			"-hls_segment_filename", segmentPattern,
+			"-f", "hls",
+			playlistPath,
+		)
+		if err := cmd.Run(); err != nil {
+			return outputDir, "", fmt.Errorf("couldn't transcode %s rendition: %w", r.Name, err)
+		}
+	}
+
+	masterPath = filepath.Join(outputDir, "master.m3u8")
+	if err := writeMasterPlaylist(masterPath, renditions); err != nil {
+		return outputDir, "", err
+	}
+
+	return outputDir, masterPath, nil
+}
+
+func writeMasterPlaylist(masterPath string, renditions []Rendition) error {
+	f, err := os.Create(masterPath)
+	if err != nil {
+		return fmt.Errorf("couldn't create master playlist: %w", err)
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, "#EXTM3U")
+	fmt.Fprintln(f, "#EXT-X-VERSION:3")
+	for _, r := range renditions {
+		bandwidth := bitrateToBandwidth(r.Bitrate)
+		fmt.Fprintf(f, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n", bandwidth, r.Width, r.Height)
+		fmt.Fprintf(f, "%s/stream.m3u8\n", r.Name)
+	}
+
+	return nil
+}
+
+// bitrateToBandwidth converts a ffmpeg-style bitrate string like "2500k"
+// into an approximate bits-per-second value for EXT-X-STREAM-INF.
+func bitrateToBandwidth(bitrate string) int {
+	var kbps int
+	fmt.Sscanf(bitrate, "%dk", &kbps)
+	return kbps * 1000
+}
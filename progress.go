@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// UploadProgress is a point-in-time snapshot of how far along a video's
+// upload pipeline is, reported over the /upload_progress SSE endpoint.
+type UploadProgress struct {
+	BytesReceived int64  `json:"bytesReceived"`
+	BytesTotal    int64  `json:"bytesTotal"`
+	Stage         string `json:"stage"` // "receiving", "transcoding", or "uploading"
+}
+
+// progressStore tracks the latest UploadProgress per video in memory. It's
+// deliberately not persisted: progress is only meaningful for the lifetime
+// of a single upload, and losing it on restart is fine.
+type progressStore struct {
+	mu      sync.Mutex
+	byVideo map[uuid.UUID]*UploadProgress
+}
+
+func newProgressStore() *progressStore {
+	return &progressStore{byVideo: make(map[uuid.UUID]*UploadProgress)}
+}
+
+func (s *progressStore) set(videoID uuid.UUID, p UploadProgress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byVideo[videoID] = &p
+}
+
+func (s *progressStore) get(videoID uuid.UUID) (UploadProgress, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.byVideo[videoID]
+	if !ok {
+		return UploadProgress{}, false
+	}
+	return *p, true
+}
+
+func (s *progressStore) delete(videoID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.byVideo, videoID)
+}
+
+// progressReader wraps an io.Reader and reports running byte counts for
+// videoID to store as data flows through Read, so a handler can track
+// upload/transcode progress without buffering anything itself.
+type progressReader struct {
+	r        io.Reader
+	store    *progressStore
+	videoID  uuid.UUID
+	stage    string
+	expected int64
+	read     int64
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.read += int64(n)
+	pr.store.set(pr.videoID, UploadProgress{
+		BytesReceived: pr.read,
+		BytesTotal:    pr.expected,
+		Stage:         pr.stage,
+	})
+	return n, err
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/transcode"
+	"github.com/google/uuid"
+)
+
+// pendingTranscode carries the bits startVideoProcessing learned about the
+// upload forward to the async worker, since transcode.Job only tracks the
+// video ID and local input path.
+type pendingTranscode struct {
+	prefix string
+}
+
+// handleTranscodeResult is the transcode.Queue's onComplete callback: it
+// uploads the finished HLS ladder and updates the video's status and URL.
+func (cfg *apiConfig) handleTranscodeResult(result transcode.Result) {
+	defer os.RemoveAll(result.OutputDir)
+	defer os.Remove(result.InputPath)
+	defer cfg.uploadProgress.delete(result.VideoID)
+
+	pendingVal, ok := cfg.pendingTranscodes.LoadAndDelete(result.VideoID)
+	if !ok {
+		log.Printf("no pending transcode state for video %s", result.VideoID)
+		return
+	}
+	pending := pendingVal.(pendingTranscode)
+
+	video, err := cfg.db.GetVideo(result.VideoID)
+	if err != nil {
+		log.Printf("couldn't load video %s after transcode: %v", result.VideoID, err)
+		return
+	}
+
+	if result.Err != nil {
+		log.Printf("transcode failed for video %s: %v", result.VideoID, result.Err)
+		video.Status = "failed"
+		if err := cfg.db.UpdateVideo(video); err != nil {
+			log.Printf("couldn't mark video %s as failed: %v", result.VideoID, err)
+		}
+		return
+	}
+
+	manifestKey, err := cfg.uploadHLSOutput(result.VideoID, result.OutputDir, pending.prefix)
+	if err != nil {
+		log.Printf("couldn't upload HLS output for video %s: %v", result.VideoID, err)
+		video.Status = "failed"
+		if err := cfg.db.UpdateVideo(video); err != nil {
+			log.Printf("couldn't mark video %s as failed: %v", result.VideoID, err)
+		}
+		return
+	}
+
+	video.VideoURL = &manifestKey
+	video.Status = "ready"
+	if err := cfg.db.UpdateVideo(video); err != nil {
+		log.Printf("couldn't update video %s after transcode: %v", result.VideoID, err)
+	}
+}
+
+// uploadHLSOutput walks the directory BuildHLS produced and uploads every
+// file under it to "hls/<prefix>/...", returning the key of master.m3u8.
+func (cfg *apiConfig) uploadHLSOutput(videoID uuid.UUID, outputDir, prefix string) (string, error) {
+	manifestKey := ""
+
+	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("hls/%s/%s", prefix, filepath.ToSlash(rel))
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		trackedBody := &progressReader{
+			r:        f,
+			store:    cfg.uploadProgress,
+			videoID:  videoID,
+			stage:    "uploading",
+			expected: info.Size(),
+		}
+
+		if err := cfg.fileStore.PutObject(context.Background(), key, contentType, trackedBody); err != nil {
+			return fmt.Errorf("couldn't upload %s: %w", key, err)
+		}
+
+		if rel == "master.m3u8" {
+			manifestKey = key
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if manifestKey == "" {
+		return "", fmt.Errorf("master.m3u8 missing from transcode output")
+	}
+
+	return manifestKey, nil
+}